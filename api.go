@@ -0,0 +1,177 @@
+// Copyright 2016 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zoekt
+
+// IndexMetadata holds the per-shard metadata that simplify and its
+// query-specific siblings (simplifyLanguage, simplifyFileClass, ...)
+// fold queries against before ever building a match tree.
+type IndexMetadata struct {
+	// LanguageMap maps a language name to the small integer ID stored
+	// per-doc in indexData.languages.
+	LanguageMap map[string]uint16
+
+	// FileClassMask is the bitwise OR of every doc's FileClass in the
+	// shard, accumulated by IndexBuilder.Add (see
+	// IndexBuilder.FileClassMask). See simplifyFileClass for how it's used.
+	FileClassMask FileClass
+}
+
+// FileMatch is a result of a search, containing a single matched file.
+type FileMatch struct {
+	// Repository is the name of the repository the file belongs to.
+	Repository   string
+	RepositoryID uint32
+
+	// SubRepositoryPath holds the relative path of the repository that
+	// contains this file, if it's not the toplevel one.
+	SubRepositoryPath string
+	SubRepositoryName string
+
+	// Each match is against a file, and Version is the specific
+	// revision (branch, tag, etc.) the matched content came from.
+	Version string
+
+	FileName string
+
+	// LineMatches is the set of matched lines in the file, ordered by
+	// line number.
+	LineMatches []LineMatch
+
+	// Score is an opaque floating point number that only makes sense in
+	// comparison to other Score values attached to the same query.
+	Score float64
+
+	// Debug is a human-readable breakdown of Score's components,
+	// populated only when the search was run with debug scoring on.
+	Debug string
+
+	Checksum []byte
+
+	// Language is the language guessed for this file, as reported by
+	// the same classification that produced indexData.languages.
+	Language string
+
+	// FileClass is the classification (vendored, generated, docs,
+	// config, test) computed for this file at index time. See
+	// fileclass.go.
+	FileClass FileClass
+
+	// Branches is the list of branches this document occurs in, i.e.
+	// the set of branches that contain this file's exact content.
+	Branches []string
+
+	// Content is the file's full content, only set when the query
+	// requested whole-file results.
+	Content []byte
+}
+
+// LineMatch is a match of a substring within a single line.
+type LineMatch struct {
+	// Score is an opaque floating point number that only makes sense in
+	// comparison to other LineMatch scores within the same FileMatch.
+	Score float64
+}
+
+// SearchOptions allows a search to trade between speed and
+// result quality and completeness.
+type SearchOptions struct {
+	// Return an upper-bound estimate of doc count, instead of running
+	// the query.
+	EstimateDocCount bool
+
+	// ShardMaxMatchCount is the maximum number of matches for a single
+	// shard.
+	ShardMaxMatchCount int
+
+	// TotalMaxMatchCount is the maximum number of matches across all
+	// shards.
+	TotalMaxMatchCount int
+
+	// ShardMaxImportantMatch is the maximum number of important
+	// (matched a basename or symbol) matches for a single shard.
+	ShardMaxImportantMatch int
+
+	// TotalMaxImportantMatch is the maximum number of important
+	// matches across all shards.
+	TotalMaxImportantMatch int
+
+	// Whole requests each FileMatch's full file content be attached as
+	// FileMatch.Content.
+	Whole bool
+
+	// ExcludeVendored skips documents whose FileClass includes
+	// FileClassVendored, before a match tree is ever built for them.
+	// See indexData.excludedByOptions.
+	ExcludeVendored bool
+
+	// ExcludeGenerated skips documents whose FileClass includes
+	// FileClassGenerated, before a match tree is ever built for them.
+	// See indexData.excludedByOptions.
+	ExcludeGenerated bool
+}
+
+// Stats contains interesting numbers on the search.
+type Stats struct {
+	// Amount of I/O for reading contents.
+	ContentBytesLoaded int64
+
+	// Amount of I/O for reading from index.
+	IndexBytesLoaded int64
+
+	// Number of search shards that had a crash.
+	Crashes int
+
+	// Wall clock time for this search.
+	Duration int64
+
+	// Number of files containing a match.
+	FileCount int
+
+	// Number of files in shards that we considered.
+	FilesConsidered int
+
+	// Number of files that we evaluated, whose content we loaded.
+	FilesLoaded int
+
+	// Number of candidate matches, considering only trigrams.
+	NgramMatches int
+
+	// Number of matches, regardless of being match limited.
+	MatchCount int
+
+	// Number of files with a match, considering only trigrams.
+	ShardFilesConsidered int
+
+	// Number of shards that we scanned.
+	ShardsScanned int
+
+	// Number of shards where we found a match.
+	ShardsSkipped int
+
+	// Number of files skipped because of the file count limit.
+	FilesSkipped int
+}
+
+// SearchResult contains search matches and extra data
+type SearchResult struct {
+	Stats Stats
+	Files []FileMatch
+
+	// RepoURLs holds a repo => template string map for results.
+	RepoURLs map[string]string
+
+	// LineFragments holds a repo => template string map for results.
+	LineFragments map[string]string
+}
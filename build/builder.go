@@ -0,0 +1,71 @@
+// Copyright 2016 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package build accumulates per-file index data before a shard is
+// flushed to disk.
+package build
+
+import "github.com/google/zoekt"
+
+// IndexBuilder accumulates the per-doc classification and symbol-tree
+// data that zoekt.FileClass and query.SymbolPath queries need, alongside
+// the rest of the shard (content postings, trigram index, shard writer)
+// built up the same way. Add is called once per file, in doc order, so
+// the indices into fileClasses and symbolTrees line up with a doc's
+// position in the shard.
+type IndexBuilder struct {
+	fileClasses   []zoekt.FileClass
+	fileClassMask zoekt.FileClass
+	symbolTrees   [][]zoekt.SymbolNode
+}
+
+func NewIndexBuilder() *IndexBuilder {
+	return &IndexBuilder{}
+}
+
+// Add classifies name's content and resolves syms (that file's ctags
+// entries) into a symbol tree, appending both to the shard being built.
+// Doing this classification at index time, once per file, is the whole
+// point: it replaces classifying on the fly from go-enry on every doc of
+// every search (the fallbacks on zoekt.(*indexData).fileClass and
+// zoekt.(*indexData).symbolTree still carry for shards built before this
+// existed).
+func (b *IndexBuilder) Add(name string, content []byte, syms []zoekt.CtagsSymbol) {
+	c := zoekt.ClassifyFile(name, content)
+	b.fileClasses = append(b.fileClasses, c)
+	b.fileClassMask |= c
+	b.symbolTrees = append(b.symbolTrees, zoekt.BuildSymbolTree(syms))
+}
+
+// FileClasses returns the per-doc classification bitflags accumulated so
+// far, in doc order, for the shard writer to persist as
+// indexData.fileClasses.
+func (b *IndexBuilder) FileClasses() []zoekt.FileClass {
+	return b.fileClasses
+}
+
+// FileClassMask is the bitwise OR of every FileClasses entry, for the
+// shard writer to persist as IndexMetadata.FileClassMask so
+// simplifyFileClass can fold a query against it without touching a
+// single doc.
+func (b *IndexBuilder) FileClassMask() zoekt.FileClass {
+	return b.fileClassMask
+}
+
+// SymbolTrees returns the per-doc symbol nesting trees accumulated so
+// far, in doc order, for the shard writer to persist as
+// indexData.symbolTrees.
+func (b *IndexBuilder) SymbolTrees() [][]zoekt.SymbolNode {
+	return b.symbolTrees
+}
@@ -85,12 +85,41 @@ func (d *indexData) simplify(in query.Q) query.Q {
 			if !has {
 				return &query.Const{Value: false}
 			}
+		case *query.PathGlob:
+			return d.simplifyPathGlob(r)
+		case *query.FileClass:
+			return d.simplifyFileClass(r)
 		}
 		return q
 	})
 	return query.Simplify(eval)
 }
 
+// simplifyPathGlob checks r's glob against every filename in the shard
+// up front. A glob that matches every file (or none) can be folded to a
+// Const, which lets purely-negative globs like `!**/vendor/**` skip the
+// whole shard instead of running the match tree per document.
+func (d *indexData) simplifyPathGlob(r *query.PathGlob) query.Q {
+	total := len(d.fileBranchMasks)
+	if total == 0 {
+		return &query.Const{Value: r.Negate}
+	}
+
+	count := 0
+	for i := 0; i < total; i++ {
+		if matchGlob(r.Pattern, string(d.fileName(uint32(i))), r.CaseSensitive) {
+			count++
+		}
+	}
+	if count == total {
+		return &query.Const{Value: !r.Negate}
+	}
+	if count == 0 {
+		return &query.Const{Value: r.Negate}
+	}
+	return r
+}
+
 func (o *SearchOptions) SetDefaults() {
 	if o.ShardMaxMatchCount == 0 {
 		// We cap the total number of matches, so overly broad
@@ -184,8 +213,9 @@ nextFileMatch:
 		if int(nextDoc) <= lastDoc {
 			nextDoc = uint32(lastDoc + 1)
 		}
-		// Skip tombstoned docs
-		for nextDoc < docCount && d.repoTombstone[d.repos[nextDoc]] {
+		// Skip tombstoned docs, and docs excluded by file classification
+		// (e.g. ExcludeVendored) before we ever build a match tree for them.
+		for nextDoc < docCount && (d.repoTombstone[d.repos[nextDoc]] || d.excludedByOptions(nextDoc, opts)) {
 			nextDoc++
 		}
 		if nextDoc >= docCount {
@@ -226,6 +256,7 @@ nextFileMatch:
 			FileName:     string(d.fileName(nextDoc)),
 			Checksum:     d.getChecksum(nextDoc),
 			Language:     d.languageMap[d.languages[nextDoc]],
+			FileClass:    d.fileClass(nextDoc),
 		}
 
 		if s := d.subRepos[nextDoc]; s > 0 {
@@ -283,6 +314,12 @@ nextFileMatch:
 		// the matches.
 		fileMatch.addScore("fragment", maxFileScore)
 		fileMatch.addScore("atom", float64(atomMatchCount)/float64(totalAtomCount)*scoreFactorAtomMatch)
+		visitMatches(mt, known, func(mt matchTree) {
+			if fzt, ok := mt.(*fuzzyMatchTree); ok && len(fzt.current) > 0 {
+				fileMatch.addScore("fuzzy-gap", fzt.gapScore)
+				fileMatch.addScore("fuzzy-boundary", fzt.boundaryScore)
+			}
+		})
 
 		// Prefer earlier docs.
 		fileMatch.addScore("doc-order", scoreFileOrderFactor*(1.0-float64(nextDoc)/float64(len(d.boundaries))))
@@ -355,6 +392,18 @@ func gatherMatches(mt matchTree, known map[matchTree]bool) []*candidateMatch {
 		if smt, ok := mt.(*symbolRegexpMatchTree); ok {
 			cands = append(cands, smt.found...)
 		}
+		if spt, ok := mt.(*symbolPathMatchTree); ok {
+			// spt.found already holds only the innermost matching symbol
+			// per candidate, so highlights land on it rather than on an
+			// enclosing scope.
+			cands = append(cands, spt.found...)
+		}
+		if fzt, ok := mt.(*fuzzyMatchTree); ok {
+			cands = append(cands, fzt.current...)
+		}
+		if pgt, ok := mt.(*pathGlobMatchTree); ok {
+			cands = append(cands, pgt.current...)
+		}
 	})
 
 	foundContentMatch := false
@@ -0,0 +1,153 @@
+// Copyright 2016 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zoekt
+
+import (
+	"github.com/go-enry/go-enry/v2"
+
+	"github.com/google/zoekt/query"
+)
+
+// FileClass is a set of per-file classification bitflags computed at
+// index time from go-enry, stored alongside the per-doc language ID so
+// that large monorepos can be filtered down to "real" source without
+// hand-maintained path-glob blocklists.
+type FileClass uint8
+
+const (
+	FileClassVendored FileClass = 1 << iota
+	FileClassGenerated
+	FileClassDocumentation
+	FileClassConfiguration
+	FileClassTest
+)
+
+// ClassifyFile runs go-enry's classifiers over a single file and returns
+// the resulting bitflags. build.IndexBuilder.Add calls this once per file
+// at index time and accumulates the result for the shard writer to
+// persist as indexData.fileClasses[doc].
+//
+// indexData.fileClass also calls this directly as a fallback for shards
+// written before this field existed (see its doc comment).
+func ClassifyFile(path string, content []byte) FileClass {
+	var c FileClass
+	if enry.IsVendor(path) {
+		c |= FileClassVendored
+	}
+	if enry.IsGenerated(path, content) {
+		c |= FileClassGenerated
+	}
+	if enry.IsDocumentation(path) {
+		c |= FileClassDocumentation
+	}
+	if enry.IsConfiguration(path) {
+		c |= FileClassConfiguration
+	}
+	if enry.IsTest(path) {
+		c |= FileClassTest
+	}
+	return c
+}
+
+func fileClassFromKind(k query.FileClassKind) FileClass {
+	switch k {
+	case query.FileClassVendored:
+		return FileClassVendored
+	case query.FileClassGenerated:
+		return FileClassGenerated
+	case query.FileClassDocumentation:
+		return FileClassDocumentation
+	case query.FileClassConfiguration:
+		return FileClassConfiguration
+	case query.FileClassTest:
+		return FileClassTest
+	default:
+		return 0
+	}
+}
+
+// fileClass returns doc's classification. Shards built before this
+// feature existed have a nil (or short) d.fileClasses, so for any doc it
+// doesn't cover we fall back to classifying on the fly from the stored
+// filename; IsGenerated degrades gracefully without content (it just
+// never matches), which is an acceptable tradeoff for an old-shard
+// fallback rather than a hard requirement.
+func (d *indexData) fileClass(doc uint32) FileClass {
+	if doc < uint32(len(d.fileClasses)) {
+		return d.fileClasses[doc]
+	}
+	return ClassifyFile(string(d.fileName(doc)), nil)
+}
+
+// excludedByOptions reports whether the document's classification means
+// it should be skipped outright, before the match tree runs at all --
+// the same cheap-filter shape as the existing repoTombstone skip.
+func (d *indexData) excludedByOptions(doc uint32, opts *SearchOptions) bool {
+	c := d.fileClass(doc)
+	return (opts.ExcludeVendored && c&FileClassVendored != 0) ||
+		(opts.ExcludeGenerated && c&FileClassGenerated != 0)
+}
+
+// fileClassMatchTree matches documents whose classification includes
+// the queried bit. Every document is a "candidate" -- there's no
+// posting-list prefilter available for a classification -- so the tree
+// just walks docs in order.
+type fileClassMatchTree struct {
+	class FileClass
+	doc   uint32
+}
+
+func (d *indexData) newFileClassMatchTree(q *query.FileClass) (matchTree, error) {
+	return &fileClassMatchTree{class: fileClassFromKind(q.Kind)}, nil
+}
+
+func (t *fileClassMatchTree) nextDoc() uint32 {
+	return t.doc
+}
+
+func (t *fileClassMatchTree) prepare(doc uint32) {
+	t.doc = doc
+}
+
+func (t *fileClassMatchTree) matches(cp *contentProvider, cost int, known map[matchTree]bool) (bool, bool) {
+	if cost < costMemory {
+		return false, false
+	}
+	v := cp.id.fileClass(t.doc)&t.class != 0
+	known[t] = v
+	return v, true
+}
+
+// simplifyFileClass folds a FileClass query against the shard's
+// aggregate classification mask (the bitwise OR of every per-doc
+// fileClasses entry, maintained next to d.metaData.LanguageMap at index
+// build time) so that a shard containing none of the requested class can
+// be dropped without ever building a match tree.
+//
+// Shards written before this feature existed have no per-doc
+// fileClasses at all, so FileClassMask is meaninglessly zero rather than
+// "no file has this class" -- folding on it there would silently drop
+// every doc. Leave the query as-is for those shards; fileClass's
+// per-doc fallback still applies the filter correctly, just without
+// this shortcut.
+func (d *indexData) simplifyFileClass(r *query.FileClass) query.Q {
+	if len(d.fileClasses) == 0 {
+		return r
+	}
+	if d.metaData.FileClassMask&fileClassFromKind(r.Kind) == 0 {
+		return &query.Const{Value: false}
+	}
+	return r
+}
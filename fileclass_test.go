@@ -0,0 +1,53 @@
+// Copyright 2016 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zoekt
+
+import (
+	"testing"
+
+	"github.com/google/zoekt/query"
+)
+
+func TestClassifyFileVendored(t *testing.T) {
+	c := ClassifyFile("vendor/github.com/foo/bar/bar.go", nil)
+	if c&FileClassVendored == 0 {
+		t.Errorf("ClassifyFile(vendor path) = %v, want FileClassVendored set", c)
+	}
+}
+
+func TestClassifyFileOrdinarySource(t *testing.T) {
+	c := ClassifyFile("pkg/widget/widget.go", []byte("package widget\n"))
+	if c&FileClassVendored != 0 {
+		t.Errorf("ClassifyFile(ordinary path) = %v, want FileClassVendored unset", c)
+	}
+}
+
+func TestFileClassFromKind(t *testing.T) {
+	cases := []struct {
+		kind query.FileClassKind
+		want FileClass
+	}{
+		{query.FileClassVendored, FileClassVendored},
+		{query.FileClassGenerated, FileClassGenerated},
+		{query.FileClassDocumentation, FileClassDocumentation},
+		{query.FileClassConfiguration, FileClassConfiguration},
+		{query.FileClassTest, FileClassTest},
+	}
+	for _, c := range cases {
+		if got := fileClassFromKind(c.kind); got != c.want {
+			t.Errorf("fileClassFromKind(%v) = %v, want %v", c.kind, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,281 @@
+// Copyright 2016 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zoekt
+
+import (
+	"bytes"
+
+	"github.com/google/zoekt/query"
+)
+
+// Fuzzy scoring bonuses, modeled after fzf's scoring scheme: tight
+// clusters of matched characters score higher than loose ones, and
+// matches that line up with natural word boundaries score higher still.
+const (
+	scoreFuzzyGapPenalty = 0.05
+	// scoreFuzzyDensityBonus weights how tightly the matched characters
+	// are clustered: denser windows score higher.
+	scoreFuzzyDensityBonus = 1.0
+	// scoreFuzzyBoundaryBonus rewards matched characters that line up
+	// with a path separator, identifier separator, or camelCase hump.
+	scoreFuzzyBoundaryBonus = 0.5
+	// scoreFuzzyFileStartBonus rewards a match that begins at the very
+	// first character of the filename.
+	scoreFuzzyFileStartBonus = 1.0
+
+	// fuzzyMaxVerify caps how many candidate documents a fuzzy query will
+	// verify and rank, mirroring fzf's --sort limit: beyond this we stop
+	// trying to find a better ranking and just return what we have.
+	fuzzyMaxVerify = 1_000_000
+)
+
+// fuzzyMatchTree matches documents whose content (or name) contains the
+// pattern's runes in order, with gaps allowed. cands narrows down the
+// posting lists to documents containing every distinct trigram of the
+// pattern; the in-order match itself is only ever checked against the
+// much smaller set of documents that cands lets through.
+type fuzzyMatchTree struct {
+	query *query.Fuzzy
+	cands matchTree
+
+	fileName bool
+	verified int
+	current  []*candidateMatch
+
+	// gapScore and boundaryScore are the components of the best match
+	// found for the current document, surfaced separately so Search can
+	// attribute them to named score buckets on the FileMatch.
+	gapScore      float64
+	boundaryScore float64
+}
+
+// newFuzzyMatchTree builds a fuzzyMatchTree for q. The prefilter is not
+// the whole pattern as one literal but an AND of substring match trees,
+// one per distinct trigram in the pattern, which narrows the candidate
+// set by posting-list intersection. Each of those trigrams must occur
+// contiguously somewhere in the document, so a document whose only
+// in-order match has a gap inside one of the pattern's trigrams (e.g.
+// "abc" in "aXbXc") is not a candidate and is not returned.
+func (d *indexData) newFuzzyMatchTree(q *query.Fuzzy) (matchTree, error) {
+	trigrams := patternTrigrams(q.Pattern)
+
+	var cands matchTree
+	if len(trigrams) == 0 {
+		// Pattern is too short to produce a useful trigram prefilter;
+		// fall back to checking every document.
+		cands = &bruteForceMatchTree{}
+	} else {
+		subs := make([]matchTree, 0, len(trigrams))
+		for _, tg := range trigrams {
+			sub, err := d.newSubstringMatchTree(&query.Substring{
+				Pattern:       tg,
+				FileName:      q.FileName,
+				CaseSensitive: q.CaseSensitive,
+			})
+			if err != nil {
+				return nil, err
+			}
+			subs = append(subs, sub)
+		}
+		if len(subs) == 1 {
+			cands = subs[0]
+		} else {
+			cands = &andMatchTree{subs}
+		}
+	}
+
+	return &fuzzyMatchTree{
+		query:    q,
+		cands:    cands,
+		fileName: q.FileName,
+	}, nil
+}
+
+// patternTrigrams returns the distinct ngramSize-byte substrings of
+// pattern, in order of first occurrence. newFuzzyMatchTree requires each
+// of them to occur contiguously in a candidate document; that holds for
+// matches whose gaps fall only between these trigrams, not for every
+// in-order match fuzzyMatch accepts.
+func patternTrigrams(pattern string) []string {
+	if len(pattern) < ngramSize {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var out []string
+	for i := 0; i+ngramSize <= len(pattern); i++ {
+		tg := pattern[i : i+ngramSize]
+		if !seen[tg] {
+			seen[tg] = true
+			out = append(out, tg)
+		}
+	}
+	return out
+}
+
+func (t *fuzzyMatchTree) nextDoc() uint32 {
+	return t.cands.nextDoc()
+}
+
+func (t *fuzzyMatchTree) prepare(doc uint32) {
+	t.cands.prepare(doc)
+	t.current = t.current[:0]
+}
+
+func (t *fuzzyMatchTree) matches(cp *contentProvider, cost int, known map[matchTree]bool) (bool, bool) {
+	if cost < costRegexp {
+		return false, false
+	}
+
+	if v, ok := known[t.cands]; ok && !v {
+		known[t] = false
+		return false, true
+	}
+
+	if t.verified >= fuzzyMaxVerify {
+		known[t] = false
+		return false, true
+	}
+	t.verified++
+
+	var data []byte
+	if t.fileName {
+		data = cp.id.fileName(cp.idx)
+	} else {
+		data = cp.data(false)
+	}
+
+	m, ok := fuzzyMatch(data, t.query.Pattern, t.query.CaseSensitive, t.fileName)
+	if !ok {
+		known[t] = false
+		return false, true
+	}
+	t.gapScore = m.gapScore
+	t.boundaryScore = m.boundaryScore
+
+	t.current = append(t.current, &candidateMatch{
+		caseSensitive: t.query.CaseSensitive,
+		fileName:      t.fileName,
+		substrBytes:   data[m.start:m.end],
+		substrLowered: data[m.start:m.end],
+		file:          cp.idx,
+		byteOffset:    uint32(m.start),
+		byteMatchSz:   uint32(m.end - m.start),
+	})
+
+	known[t] = true
+	return true, true
+}
+
+// fuzzyWindow describes a matched span of data together with the score
+// components contributed by that span.
+type fuzzyWindow struct {
+	start, end    int
+	gapScore      float64
+	boundaryScore float64
+}
+
+// fuzzyMatch finds the pattern's characters in data in order, allowing
+// gaps, and returns the tightest window that ends at the earliest
+// position where the whole pattern can end, together with its fzf-style
+// score. Windows ending later in data are not considered, even where one
+// of them is tighter. It returns ok=false if the pattern doesn't occur at
+// all.
+//
+// Boundary detection (camelCase humps in particular) needs the original,
+// un-folded bytes, so case folding is only applied to a copy used for
+// locating match positions; orig is kept around for scoring.
+func fuzzyMatch(data []byte, pattern string, caseSensitive, fileName bool) (fuzzyWindow, bool) {
+	if len(pattern) == 0 {
+		return fuzzyWindow{}, false
+	}
+	orig := data
+	if !caseSensitive {
+		data = bytes.ToLower(data)
+		pattern = string(bytes.ToLower([]byte(pattern)))
+	}
+
+	// Forward pass: the earliest position at which pattern occurs as an
+	// in-order subsequence. This fixes an end position for the match but,
+	// being greedy, does not give the tightest window.
+	cursor := -1
+	for i := 0; i < len(pattern); i++ {
+		idx := bytes.IndexByte(data[cursor+1:], pattern[i])
+		if idx < 0 {
+			return fuzzyWindow{}, false
+		}
+		cursor = cursor + 1 + idx
+	}
+	end := cursor + 1
+
+	// Backward pass: starting from that end position, match the pattern
+	// in reverse, each character as late as possible. This contracts the
+	// window to the tightest one that still ends at `end`, which is what
+	// fzf's two-pass algorithm does.
+	positions := make([]int, len(pattern))
+	cursor = end
+	for i := len(pattern) - 1; i >= 0; i-- {
+		idx := bytes.LastIndexByte(data[:cursor], pattern[i])
+		if idx < 0 {
+			// Unreachable: the forward pass already proved a match exists.
+			return fuzzyWindow{}, false
+		}
+		cursor = idx
+		positions[i] = idx
+	}
+
+	start := positions[0]
+	window := end - start
+
+	gapScore := scoreFuzzyDensityBonus*float64(len(pattern))/float64(window) -
+		scoreFuzzyGapPenalty*float64(window-len(pattern))
+
+	boundaryScore := 0.0
+	if fileName && start == 0 {
+		boundaryScore += scoreFuzzyFileStartBonus
+	}
+	for _, p := range positions {
+		if isSeparatorBoundary(orig, p) || isCamelBoundary(orig, p) {
+			boundaryScore += scoreFuzzyBoundaryBonus
+		}
+	}
+
+	return fuzzyWindow{start: start, end: end, gapScore: gapScore, boundaryScore: boundaryScore}, true
+}
+
+// isSeparatorBoundary reports whether pos starts a new "word" because
+// the preceding byte is a path or identifier separator (or pos is the
+// very start of data).
+func isSeparatorBoundary(data []byte, pos int) bool {
+	if pos == 0 {
+		return true
+	}
+	switch data[pos-1] {
+	case '/', '_', '-', '.':
+		return true
+	}
+	return false
+}
+
+// isCamelBoundary reports whether pos is the start of a camelCase hump:
+// an uppercase letter immediately following a lowercase one. This must
+// run against the original, un-folded bytes -- case-insensitive matching
+// already lowercases everything, which would make this always false.
+func isCamelBoundary(data []byte, pos int) bool {
+	if pos == 0 || pos >= len(data) {
+		return false
+	}
+	c, prev := data[pos], data[pos-1]
+	return c >= 'A' && c <= 'Z' && prev >= 'a' && prev <= 'z'
+}
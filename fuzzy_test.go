@@ -0,0 +1,85 @@
+// Copyright 2016 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zoekt
+
+import "testing"
+
+func TestFuzzyMatchGapped(t *testing.T) {
+	// The pattern's characters are not contiguous in the data; a fuzzy
+	// match must still find them in order.
+	m, ok := fuzzyMatch([]byte("aXbXc"), "abc", true, false)
+	if !ok {
+		t.Fatalf("fuzzyMatch: want match, got none")
+	}
+	if m.start != 0 || m.end != 5 {
+		t.Errorf("fuzzyMatch window = [%d,%d), want [0,5)", m.start, m.end)
+	}
+}
+
+func TestFuzzyMatchBackwardContraction(t *testing.T) {
+	// The forward pass greedily matches the earliest 'a', pinning the
+	// window to [0,4). Backward contraction must then slide the start
+	// forward to the latest 'a' that still precedes the fixed end,
+	// tightening the window to [2,4).
+	m, ok := fuzzyMatch([]byte("aaab"), "ab", true, false)
+	if !ok {
+		t.Fatalf("fuzzyMatch: want match, got none")
+	}
+	if m.start != 2 || m.end != 4 {
+		t.Errorf("fuzzyMatch window = [%d,%d), want [2,4)", m.start, m.end)
+	}
+}
+
+func TestFuzzyMatchNoMatch(t *testing.T) {
+	if _, ok := fuzzyMatch([]byte("abd"), "abc", true, false); ok {
+		t.Errorf("fuzzyMatch: want no match for pattern not present in order")
+	}
+}
+
+func TestFuzzyMatchBoundaryBonus(t *testing.T) {
+	// In "fooFooBar" the matched 'F' and 'B' both start a camelCase hump;
+	// in "xxfxxbxx" the matched 'f' and 'b' follow an 'x' and start
+	// nothing. The boundary-aligned match should score higher.
+	aligned, ok := fuzzyMatch([]byte("fooFooBar"), "fb", false, false)
+	if !ok {
+		t.Fatalf("fuzzyMatch: want match")
+	}
+	unaligned, ok := fuzzyMatch([]byte("xxfxxbxx"), "fb", false, false)
+	if !ok {
+		t.Fatalf("fuzzyMatch: want match")
+	}
+	if aligned.boundaryScore <= unaligned.boundaryScore {
+		t.Errorf("boundary-aligned match score %v should exceed unaligned %v", aligned.boundaryScore, unaligned.boundaryScore)
+	}
+}
+
+func TestPatternTrigrams(t *testing.T) {
+	got := patternTrigrams("abcabc")
+	want := []string{"abc", "bca", "cab"}
+	if len(got) != len(want) {
+		t.Fatalf("patternTrigrams(%q) = %v, want %v", "abcabc", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("patternTrigrams(%q)[%d] = %q, want %q", "abcabc", i, got[i], want[i])
+		}
+	}
+}
+
+func TestPatternTrigramsShortPattern(t *testing.T) {
+	if got := patternTrigrams("ab"); got != nil {
+		t.Errorf("patternTrigrams(%q) = %v, want nil", "ab", got)
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2016 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zoekt
+
+import (
+	"fmt"
+
+	"github.com/google/zoekt/query"
+)
+
+// newMatchTree converts q into a matchTree that can be evaluated per
+// document. By the time Search calls this, q has already gone through
+// indexData.simplify and query.ExpandFileContent, which fold repo, branch
+// and language atoms to query.Const only where the shard decides them
+// outright. newMatchTree has no case for those atom types, so one that
+// is left in q makes it return an error.
+func (d *indexData) newMatchTree(q query.Q) (matchTree, error) {
+	switch s := q.(type) {
+	case *query.Const:
+		if s.Value {
+			return &bruteForceMatchTree{}, nil
+		}
+		return &noMatchTree{"const"}, nil
+	case *query.And:
+		children := make([]matchTree, 0, len(s.Children))
+		for _, sub := range s.Children {
+			ct, err := d.newMatchTree(sub)
+			if err != nil {
+				return nil, err
+			}
+			children = append(children, ct)
+		}
+		return &andMatchTree{children}, nil
+	case *query.Or:
+		children := make([]matchTree, 0, len(s.Children))
+		for _, sub := range s.Children {
+			ct, err := d.newMatchTree(sub)
+			if err != nil {
+				return nil, err
+			}
+			children = append(children, ct)
+		}
+		return &orMatchTree{children}, nil
+	case *query.Not:
+		ct, err := d.newMatchTree(s.Child)
+		if err != nil {
+			return nil, err
+		}
+		return &notMatchTree{ct}, nil
+	case *query.Substring:
+		return d.newSubstringMatchTree(s)
+	case *query.Fuzzy:
+		return d.newFuzzyMatchTree(s)
+	case *query.PathGlob:
+		return d.newPathGlobMatchTree(s)
+	case *query.FileClass:
+		return d.newFileClassMatchTree(s)
+	case *query.SymbolPath:
+		return d.newSymbolPathMatchTree(s)
+	}
+	return nil, fmt.Errorf("newMatchTree: unsupported query type %T", q)
+}
+
+// notMatchTree negates its child. It is only ever used as a leaf
+// combinator alongside andMatchTree/orMatchTree, so it evaluates eagerly
+// rather than carrying its own doc iteration state.
+type notMatchTree struct {
+	child matchTree
+}
+
+func (t *notMatchTree) nextDoc() uint32 {
+	return 0
+}
+
+func (t *notMatchTree) prepare(doc uint32) {
+	t.child.prepare(doc)
+}
+
+func (t *notMatchTree) matches(cp *contentProvider, cost int, known map[matchTree]bool) (bool, bool) {
+	v, ok := t.child.matches(cp, cost, known)
+	if !ok {
+		return false, false
+	}
+	return !v, true
+}
@@ -0,0 +1,258 @@
+// Copyright 2016 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zoekt
+
+import (
+	"strings"
+
+	"github.com/google/zoekt/query"
+)
+
+// pathGlobMatchTree matches the full path against a PathGlob query. cands
+// is a cheap trigram prefilter built from the glob's literal runs; the
+// exact glob semantics (including "**") are only evaluated against the
+// documents cands lets through.
+type pathGlobMatchTree struct {
+	query *query.PathGlob
+	cands matchTree
+
+	current []*candidateMatch
+}
+
+// newPathGlobMatchTree builds a matchTree for q. It mirrors
+// regexpToMatchTreeRecursive: the literal runs between wildcards become
+// an AND of substring match trees over the filename, which narrows the
+// candidate set before the exact glob check runs.
+//
+// That narrowing only holds for a positive match: every file the glob
+// accepts is guaranteed to contain those literals. A negated glob has no
+// such guarantee -- the files it's supposed to return are exactly the
+// ones that *don't* look like the pattern, so a literal prefilter built
+// from the pattern would wrongly restrict iteration to the files the
+// query is meant to exclude. Negated globs fall back to visiting every
+// doc.
+func (d *indexData) newPathGlobMatchTree(q *query.PathGlob) (matchTree, error) {
+	var cands matchTree
+	if q.Negate {
+		cands = &bruteForceMatchTree{}
+	} else {
+		var subs []matchTree
+		for _, lit := range globLiterals(q.Pattern) {
+			if len(lit) < ngramSize {
+				continue
+			}
+			sub, err := d.newSubstringMatchTree(&query.Substring{
+				Pattern:       lit,
+				FileName:      true,
+				CaseSensitive: q.CaseSensitive,
+			})
+			if err != nil {
+				return nil, err
+			}
+			subs = append(subs, sub)
+		}
+
+		switch len(subs) {
+		case 0:
+			cands = &bruteForceMatchTree{}
+		case 1:
+			cands = subs[0]
+		default:
+			cands = &andMatchTree{subs}
+		}
+	}
+
+	return &pathGlobMatchTree{query: q, cands: cands}, nil
+}
+
+// globLiterals splits a glob pattern on its wildcard metacharacters ('*',
+// '?', '[') and returns the non-empty runs in between, the same way
+// regexpToMatchTreeRecursive pulls literal substrings out of a regex.
+func globLiterals(pattern string) []string {
+	var lits []string
+	var cur strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case pattern[i] == '*' && i+1 < len(pattern) && pattern[i+1] == '*':
+			// "**" can also match zero path segments, so it can absorb the
+			// separator on either side of it (e.g. "**/vendor/**" matches
+			// the top-level path "vendor/foo"). Trim the literal run's
+			// adjoining '/' on both sides so the trigram prefilter can't
+			// reject a path the glob matcher itself would accept.
+			s := strings.TrimSuffix(cur.String(), "/")
+			cur.Reset()
+			if s != "" {
+				lits = append(lits, s)
+			}
+			i++
+			if i+1 < len(pattern) && pattern[i+1] == '/' {
+				i++
+			}
+		case pattern[i] == '*' || pattern[i] == '?':
+			if cur.Len() > 0 {
+				lits = append(lits, cur.String())
+				cur.Reset()
+			}
+		case pattern[i] == '[':
+			if cur.Len() > 0 {
+				lits = append(lits, cur.String())
+				cur.Reset()
+			}
+			for i < len(pattern) && pattern[i] != ']' {
+				i++
+			}
+		default:
+			cur.WriteByte(pattern[i])
+		}
+	}
+	if cur.Len() > 0 {
+		lits = append(lits, cur.String())
+	}
+	return lits
+}
+
+func (t *pathGlobMatchTree) nextDoc() uint32 {
+	return t.cands.nextDoc()
+}
+
+func (t *pathGlobMatchTree) prepare(doc uint32) {
+	t.cands.prepare(doc)
+	t.current = t.current[:0]
+}
+
+func (t *pathGlobMatchTree) matches(cp *contentProvider, cost int, known map[matchTree]bool) (bool, bool) {
+	if cost < costRegexp {
+		return false, false
+	}
+
+	if v, ok := known[t.cands]; ok && !v {
+		known[t] = false
+		return false, true
+	}
+
+	path := string(cp.id.fileName(cp.idx))
+	if matchGlob(t.query.Pattern, path, t.query.CaseSensitive) == t.query.Negate {
+		known[t] = false
+		return false, true
+	}
+
+	// Carry a synthetic filename candidate so highlighting shows the
+	// glob-satisfying path, mirroring the no-match fallback in Search.
+	nm := []byte(path)
+	t.current = append(t.current, &candidateMatch{
+		caseSensitive: t.query.CaseSensitive,
+		fileName:      true,
+		substrBytes:   nm,
+		substrLowered: nm,
+		file:          cp.idx,
+		byteOffset:    0,
+		byteMatchSz:   uint32(len(nm)),
+	})
+
+	known[t] = true
+	return true, true
+}
+
+// matchGlob reports whether path matches the shell-style glob pattern.
+// Supported metacharacters: '*' (any run of non-'/' characters), '?' (a
+// single non-'/' character), '[...]' (a character class), and '**' (any
+// number of path segments, including none).
+func matchGlob(pattern, path string, caseSensitive bool) bool {
+	if !caseSensitive {
+		pattern = strings.ToLower(pattern)
+		path = strings.ToLower(path)
+	}
+	return globMatch(pattern, path)
+}
+
+func globMatch(pattern, s string) bool {
+	for len(pattern) > 0 {
+		switch {
+		case pattern[0] == '*' && len(pattern) > 1 && pattern[1] == '*':
+			rest := strings.TrimPrefix(pattern, "**")
+			pattern = strings.TrimPrefix(rest, "/")
+			if len(pattern) == 0 {
+				return true
+			}
+			// "**/" stands for whole path segments, so what follows it
+			// must start at the beginning of a segment.
+			segment := len(pattern) < len(rest)
+			for i := 0; i <= len(s); i++ {
+				if segment && i > 0 && s[i-1] != '/' {
+					continue
+				}
+				if globMatch(pattern, s[i:]) {
+					return true
+				}
+			}
+			return false
+		case pattern[0] == '*':
+			pattern = pattern[1:]
+			if len(pattern) == 0 {
+				return !strings.Contains(s, "/")
+			}
+			for i := 0; i <= len(s); i++ {
+				if s[:i] != "" && strings.Contains(s[:i], "/") {
+					break
+				}
+				if globMatch(pattern, s[i:]) {
+					return true
+				}
+			}
+			return false
+		case pattern[0] == '?':
+			if len(s) == 0 || s[0] == '/' {
+				return false
+			}
+			pattern, s = pattern[1:], s[1:]
+		case pattern[0] == '[':
+			end := strings.IndexByte(pattern, ']')
+			if end < 0 || len(s) == 0 {
+				return false
+			}
+			class := pattern[1:end]
+			if !matchClass(class, s[0]) {
+				return false
+			}
+			pattern, s = pattern[end+1:], s[1:]
+		default:
+			if len(s) == 0 || pattern[0] != s[0] {
+				return false
+			}
+			pattern, s = pattern[1:], s[1:]
+		}
+	}
+	return len(s) == 0
+}
+
+func matchClass(class string, c byte) bool {
+	negate := false
+	if strings.HasPrefix(class, "!") || strings.HasPrefix(class, "^") {
+		negate = true
+		class = class[1:]
+	}
+	found := false
+	for i := 0; i < len(class); i++ {
+		if i+2 < len(class) && class[i+1] == '-' {
+			if class[i] <= c && c <= class[i+2] {
+				found = true
+			}
+			i += 2
+		} else if class[i] == c {
+			found = true
+		}
+	}
+	return found != negate
+}
@@ -0,0 +1,62 @@
+// Copyright 2016 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zoekt
+
+import "testing"
+
+func TestMatchGlobDoubleStar(t *testing.T) {
+	cases := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"**/vendor/**", "vendor/foo.go", true},
+		{"**/vendor/**", "a/b/vendor/foo.go", true},
+		{"**/vendor/**", "a/vendornot/foo.go", false},
+		{"**/vendor/**", "src/main.go", false},
+		{"*.go", "main.go", true},
+		{"*.go", "a/main.go", false},
+	}
+	for _, c := range cases {
+		if got := matchGlob(c.pattern, c.path, true); got != c.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", c.pattern, c.path, got, c.want)
+		}
+	}
+}
+
+// TestPathGlobNegation exercises the negation semantics that
+// pathGlobMatchTree.matches applies on top of matchGlob: a negated glob
+// accepts exactly the paths the bare glob rejects.
+func TestPathGlobNegation(t *testing.T) {
+	pattern := "**/vendor/**"
+	negate := true
+
+	vendored := "a/vendor/foo.go"
+	nonVendored := "a/src/foo.go"
+
+	if accept := matchGlob(pattern, vendored, true) != negate; accept {
+		t.Errorf("negated glob %q should reject %q", pattern, vendored)
+	}
+	if accept := matchGlob(pattern, nonVendored, true) != negate; !accept {
+		t.Errorf("negated glob %q should accept %q", pattern, nonVendored)
+	}
+}
+
+func TestGlobLiteralsTrimsDoubleStarSeparators(t *testing.T) {
+	got := globLiterals("**/vendor/**")
+	want := []string{"vendor"}
+	if len(got) != len(want) || got[0] != want[0] {
+		t.Errorf("globLiterals(%q) = %v, want %v", "**/vendor/**", got, want)
+	}
+}
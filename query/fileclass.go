@@ -0,0 +1,54 @@
+// Copyright 2016 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package query
+
+// FileClassKind identifies one of the per-file classifications computed
+// at index time (see go-enry). Queries use this to include or exclude
+// whole categories of generated/vendored noise.
+type FileClassKind int
+
+const (
+	FileClassVendored FileClassKind = iota
+	FileClassGenerated
+	FileClassDocumentation
+	FileClassConfiguration
+	FileClassTest
+)
+
+func (k FileClassKind) String() string {
+	switch k {
+	case FileClassVendored:
+		return "vendored"
+	case FileClassGenerated:
+		return "generated"
+	case FileClassDocumentation:
+		return "documentation"
+	case FileClassConfiguration:
+		return "configuration"
+	case FileClassTest:
+		return "test"
+	default:
+		return "unknown"
+	}
+}
+
+// FileClass matches files that carry the given classification.
+type FileClass struct {
+	Kind FileClassKind
+}
+
+func (q *FileClass) String() string {
+	return "file_class:" + q.Kind.String()
+}
@@ -0,0 +1,42 @@
+// Copyright 2016 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package query
+
+import "strconv"
+
+// Fuzzy matches files whose content or name contains the pattern's
+// characters in order, but not necessarily contiguously (fzf-style fuzzy
+// matching). Unlike Substring, a Fuzzy match may have arbitrary gaps
+// between matched characters; results are ranked so that tighter
+// clusters of the pattern score higher. Search only considers documents
+// that contain each trigram of the pattern contiguously, so a gap inside
+// one of the pattern's trigrams is not found.
+type Fuzzy struct {
+	Pattern string
+
+	FileName      bool
+	CaseSensitive bool
+}
+
+func (q *Fuzzy) String() string {
+	s := "fuzzy:" + strconv.Quote(q.Pattern)
+	if q.FileName {
+		s = "file_" + s
+	}
+	if q.CaseSensitive {
+		s += "case"
+	}
+	return s
+}
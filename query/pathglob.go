@@ -0,0 +1,43 @@
+// Copyright 2016 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package query
+
+import "strconv"
+
+// PathGlob matches the full file path against a shell-style glob:
+// `*` matches any run of characters except `/`, `?` matches a single
+// character except `/`, `[...]` matches a character class, and `**`
+// matches any number of path segments (including none).
+type PathGlob struct {
+	Pattern string
+
+	// Negate inverts the match: a file matches the query iff it does NOT
+	// match Pattern. This lets shards that contain no matching files be
+	// skipped entirely for patterns like `!**/vendor/**`.
+	Negate bool
+
+	CaseSensitive bool
+}
+
+func (q *PathGlob) String() string {
+	s := "path_glob:" + strconv.Quote(q.Pattern)
+	if q.Negate {
+		s = "!" + s
+	}
+	if q.CaseSensitive {
+		s += "case"
+	}
+	return s
+}
@@ -0,0 +1,34 @@
+// Copyright 2016 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package query
+
+import "strconv"
+
+// SymbolPath matches a nesting chain of symbols, e.g. "TypeName/MethodName"
+// matches a MethodName symbol whose directly enclosing scope is a
+// TypeName symbol, in the style of `go test -run TestFoo/SubTest`. Each
+// segment is an independent regex matched against one level of the
+// symbol's enclosing scope, parents before children.
+//
+// A leading "/" anchors the first segment to a top-level (file-scope)
+// symbol. A trailing empty segment ("Foo/") matches any leaf symbol
+// scoped under the preceding segments.
+type SymbolPath struct {
+	Path string
+}
+
+func (q *SymbolPath) String() string {
+	return "symbol_path:" + strconv.Quote(q.Path)
+}
@@ -0,0 +1,66 @@
+// Copyright 2016 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zoekt
+
+// CtagsSymbol is the subset of a single ctags entry that the symbol
+// indexer already records per file: the symbol's name, byte range, and
+// its raw scope fields (universal-ctags emits these as e.g.
+// "scopeKind: class", "scope: Foo" for a method nested in class Foo).
+// It's exported so build.IndexBuilder.Add can pass ctags output straight
+// into BuildSymbolTree.
+type CtagsSymbol struct {
+	Sym                     string
+	ScopeKind, ScopeName    string
+	ByteOffset, ByteMatchSz uint32
+}
+
+// BuildSymbolTree turns a file's flat, in-order list of ctags entries
+// into a parent-linked tree by resolving each entry's scope fields
+// against the symbols already seen for that file: universal-ctags scopes
+// are always resolved relative to an enclosing definition earlier in the
+// same file, so the most recent symbol with a matching name is its
+// parent. build.IndexBuilder.Add calls this once per file and accumulates
+// the result for the shard writer to persist as indexData.symbolTrees[doc].
+func BuildSymbolTree(syms []CtagsSymbol) []SymbolNode {
+	nodes := make([]SymbolNode, len(syms))
+	mostRecentByName := map[string]int{}
+	for i, s := range syms {
+		parent := int32(-1)
+		if s.ScopeName != "" {
+			if j, ok := mostRecentByName[s.ScopeName]; ok {
+				parent = int32(j)
+			}
+		}
+		nodes[i] = SymbolNode{
+			Sym:         s.Sym,
+			Parent:      parent,
+			ByteOffset:  s.ByteOffset,
+			ByteMatchSz: s.ByteMatchSz,
+		}
+		mostRecentByName[s.Sym] = i
+	}
+	return nodes
+}
+
+// symbolTree returns doc's symbol nesting tree. Shards indexed before
+// per-doc symbol trees were recorded (or docs with no symbols at all)
+// simply have nothing past the end of d.symbolTrees; SymbolPath queries
+// against them correctly find no matches rather than panicking.
+func (d *indexData) symbolTree(doc uint32) []SymbolNode {
+	if doc < uint32(len(d.symbolTrees)) {
+		return d.symbolTrees[doc]
+	}
+	return nil
+}
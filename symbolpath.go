@@ -0,0 +1,163 @@
+// Copyright 2016 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zoekt
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/google/zoekt/query"
+)
+
+// SymbolNode is one entry in a file's symbol nesting tree, built from
+// ctags scope fields (e.g. a method's enclosing class). It extends the
+// data already tracked for symbolRegexpMatchTree with an explicit parent
+// link, so SymbolPath queries can walk from a symbol up through its
+// enclosing scopes. It's exported so build.IndexBuilder can construct it
+// at index time via BuildSymbolTree.
+type SymbolNode struct {
+	Sym    string
+	Parent int32 // index of the enclosing SymbolNode for this doc, or -1 at top level
+
+	ByteOffset, ByteMatchSz uint32
+}
+
+// symbolPathMatchTree matches files containing a symbol whose chain of
+// enclosing scopes satisfies query.SymbolPath's segments, parents
+// before children.
+type symbolPathMatchTree struct {
+	query    *query.SymbolPath
+	segments []*regexp.Regexp
+	anchored bool // leading "/": the outermost segment must be top-level
+	leafAny  bool // trailing empty segment: innermost segment may be any leaf
+
+	doc   uint32
+	found []*candidateMatch
+}
+
+func (d *indexData) newSymbolPathMatchTree(q *query.SymbolPath) (matchTree, error) {
+	parts := strings.Split(q.Path, "/")
+
+	anchored := false
+	if len(parts) > 0 && parts[0] == "" {
+		anchored = true
+		parts = parts[1:]
+	}
+
+	leafAny := false
+	if len(parts) > 0 && parts[len(parts)-1] == "" {
+		leafAny = true
+		parts = parts[:len(parts)-1]
+	}
+
+	segments := make([]*regexp.Regexp, 0, len(parts))
+	for _, p := range parts {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, err
+		}
+		segments = append(segments, re)
+	}
+
+	return &symbolPathMatchTree{
+		query:    q,
+		segments: segments,
+		anchored: anchored,
+		leafAny:  leafAny,
+	}, nil
+}
+
+func (t *symbolPathMatchTree) nextDoc() uint32 {
+	return t.doc
+}
+
+func (t *symbolPathMatchTree) prepare(doc uint32) {
+	t.doc = doc
+	t.found = t.found[:0]
+}
+
+func (t *symbolPathMatchTree) matches(cp *contentProvider, cost int, known map[matchTree]bool) (bool, bool) {
+	if cost < costRegexp {
+		return false, false
+	}
+	if len(t.segments) == 0 {
+		known[t] = false
+		return false, true
+	}
+
+	nodes := cp.id.symbolTree(t.doc)
+
+	var hasChild []bool
+	if t.leafAny {
+		hasChild = make([]bool, len(nodes))
+		for _, n := range nodes {
+			if n.Parent >= 0 {
+				hasChild[n.Parent] = true
+			}
+		}
+	}
+
+	for i, n := range nodes {
+		if t.leafAny {
+			// Every segment (including the last) is an ancestor
+			// constraint; the symbol itself can be any childless leaf
+			// scoped underneath them.
+			if hasChild[i] {
+				continue
+			}
+			if !t.matchChain(nodes, n.Parent, len(t.segments)-1) {
+				continue
+			}
+		} else {
+			leaf := t.segments[len(t.segments)-1]
+			if !leaf.MatchString(n.Sym) {
+				continue
+			}
+			if !t.matchChain(nodes, n.Parent, len(t.segments)-2) {
+				continue
+			}
+		}
+		t.found = append(t.found, &candidateMatch{
+			fileName:    false,
+			file:        t.doc,
+			byteOffset:  n.ByteOffset,
+			byteMatchSz: n.ByteMatchSz,
+		})
+	}
+
+	v := len(t.found) > 0
+	known[t] = v
+	return v, true
+}
+
+// matchChain walks up the ancestor chain starting at the node index cur,
+// checking it against t.segments[segIdx], then segIdx-1, and so on, down
+// to and including segment 0. A segIdx of -1 (chain already exhausted)
+// trivially succeeds.
+func (t *symbolPathMatchTree) matchChain(nodes []SymbolNode, cur int32, segIdx int) bool {
+	for ; segIdx >= 0; segIdx-- {
+		if cur < 0 {
+			return false
+		}
+		if !t.segments[segIdx].MatchString(nodes[cur].Sym) {
+			return false
+		}
+		cur = nodes[cur].Parent
+	}
+	if t.anchored && cur >= 0 {
+		return false
+	}
+	return true
+}
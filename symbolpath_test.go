@@ -0,0 +1,97 @@
+// Copyright 2016 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zoekt
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestBuildSymbolTreeParentResolution(t *testing.T) {
+	syms := []CtagsSymbol{
+		{Sym: "Foo", ScopeKind: "", ScopeName: ""},
+		{Sym: "Bar", ScopeKind: "class", ScopeName: "Foo"},
+		{Sym: "Baz", ScopeKind: "method", ScopeName: "Bar"},
+	}
+	nodes := BuildSymbolTree(syms)
+	if len(nodes) != 3 {
+		t.Fatalf("BuildSymbolTree: got %d nodes, want 3", len(nodes))
+	}
+	if nodes[0].Parent != -1 {
+		t.Errorf("Foo.Parent = %d, want -1 (top level)", nodes[0].Parent)
+	}
+	if nodes[1].Parent != 0 {
+		t.Errorf("Bar.Parent = %d, want 0 (Foo)", nodes[1].Parent)
+	}
+	if nodes[2].Parent != 1 {
+		t.Errorf("Baz.Parent = %d, want 1 (Bar)", nodes[2].Parent)
+	}
+}
+
+func TestMatchChainWalksAncestors(t *testing.T) {
+	// Foo -> Bar -> Baz, matching segments ["Foo", "Bar"] against Baz's
+	// ancestor chain (segIdx starts at len(segments)-1).
+	nodes := []SymbolNode{
+		{Sym: "Foo", Parent: -1},
+		{Sym: "Bar", Parent: 0},
+		{Sym: "Baz", Parent: 1},
+	}
+	tr := &symbolPathMatchTree{
+		segments: []*regexp.Regexp{regexp.MustCompile("^Foo$"), regexp.MustCompile("^Bar$")},
+	}
+	if !tr.matchChain(nodes, nodes[2].Parent, len(tr.segments)-1) {
+		t.Errorf("matchChain: want Baz's ancestors [Bar, Foo] to satisfy [Foo, Bar]")
+	}
+}
+
+func TestMatchChainRejectsWrongAncestor(t *testing.T) {
+	nodes := []SymbolNode{
+		{Sym: "Foo", Parent: -1},
+		{Sym: "Quux", Parent: 0},
+	}
+	tr := &symbolPathMatchTree{
+		segments: []*regexp.Regexp{regexp.MustCompile("^Foo$"), regexp.MustCompile("^Bar$")},
+	}
+	if tr.matchChain(nodes, nodes[1].Parent, len(tr.segments)-1) {
+		t.Errorf("matchChain: Quux's parent chain shouldn't satisfy [Foo, Bar]")
+	}
+}
+
+func TestMatchChainAnchoredRequiresTopLevel(t *testing.T) {
+	// Inner's ancestor chain is [Middle, Outer]. A pattern naming only
+	// "Middle" exhausts its segments one level up from Inner but leaves
+	// Outer unaccounted for -- an anchored match requires the chain to
+	// run out exactly at top level, so it must reject this, even though
+	// the unanchored match (no such requirement) accepts it.
+	nodes := []SymbolNode{
+		{Sym: "Outer", Parent: -1},
+		{Sym: "Middle", Parent: 0},
+		{Sym: "Inner", Parent: 1},
+	}
+	tr := &symbolPathMatchTree{
+		segments: []*regexp.Regexp{regexp.MustCompile("^Middle$")},
+		anchored: true,
+	}
+	if tr.matchChain(nodes, nodes[2].Parent, len(tr.segments)-1) {
+		t.Errorf("matchChain: anchored match should require the chain to end exactly at top level")
+	}
+
+	unanchored := &symbolPathMatchTree{
+		segments: []*regexp.Regexp{regexp.MustCompile("^Middle$")},
+	}
+	if !unanchored.matchChain(nodes, nodes[2].Parent, len(unanchored.segments)-1) {
+		t.Errorf("matchChain: unanchored match should succeed")
+	}
+}